@@ -2,13 +2,18 @@ package gitlab
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,14 +30,20 @@ import (
 )
 
 const (
-	defaultGitlabAPI  = "https://gitlab.com/api/v4"
 	defaultGitlabHost = "gitlab.com"
 	maxPerPage        = "100"
-	gitlabAPI         = "%s%s/api/v4"
+	gitlabAPIv4Fmt    = "%s%s/api/v4"
+	gitlabAPIv3Fmt    = "%s%s/api/v3"
+	apiVersionV3      = "v3"
 	descRunning       = "This build is running"
 	descPending       = "This build is pending"
 	descSuccess       = "This build is success"
 	descFailure       = "This build is failure"
+
+	// defaults for retrying rate-limited/transient GitLab API failures
+	defaultMaxRetries = 5
+	defaultBaseDelay  = 500 * time.Millisecond
+	defaultMaxDelay   = 30 * time.Second
 )
 
 type client struct {
@@ -42,6 +53,11 @@ type client struct {
 	ClientSecret string
 	RedirectURL  string
 	API          string
+	HTTPClient   *http.Client
+	MaxRetries   int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	flavor       apiFlavor
 }
 
 func New(config *v3.GitlabPipelineConfig) (model.Remote, error) {
@@ -52,6 +68,10 @@ func New(config *v3.GitlabPipelineConfig) (model.Remote, error) {
 		ClientID:     config.ClientID,
 		ClientSecret: config.ClientSecret,
 		RedirectURL:  config.RedirectURL,
+		MaxRetries:   defaultMaxRetries,
+		BaseDelay:    defaultBaseDelay,
+		MaxDelay:     defaultMaxDelay,
+		flavor:       apiFlavorFor(config.APIVersion),
 	}
 	if config.Hostname != "" && config.Hostname != defaultGitlabHost {
 		glClient.Host = config.Hostname
@@ -60,53 +80,195 @@ func New(config *v3.GitlabPipelineConfig) (model.Remote, error) {
 		} else {
 			glClient.Scheme = "http://"
 		}
-		glClient.API = fmt.Sprintf(gitlabAPI, glClient.Scheme, glClient.Host)
 	} else {
 		glClient.Scheme = "https://"
 		glClient.Host = defaultGitlabHost
-		glClient.API = defaultGitlabAPI
 	}
+	glClient.API = glClient.flavor.apiBase(glClient.Scheme, glClient.Host)
+
+	tlsConfig, err := buildTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	glClient.HTTPClient = &http.Client{
+		Timeout: 15 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
 	return glClient, nil
 }
 
+// buildTLSConfig assembles a *tls.Config for talking to self-hosted GitLab
+// instances that use a private CA or a client certificate, mirroring the
+// HTTP{User,Password,SelfSigned,CAFile,CAPath} shape used elsewhere for
+// Gitaly config.
+func buildTLSConfig(config *v3.GitlabPipelineConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.CAFile != "" || config.CAPath != "" {
+		pool, err := loadCAPool(config.CAFile, config.CAPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading gitlab CA")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertFile != "" && config.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading gitlab client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func loadCAPool(caFile, caPath string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CAFile %v", caFile)
+		}
+	}
+
+	if caPath != "" {
+		files, err := ioutil.ReadDir(caPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			pem, err := ioutil.ReadFile(caPath + "/" + f.Name())
+			if err != nil {
+				return nil, err
+			}
+			pool.AppendCertsFromPEM(pem)
+		}
+	}
+
+	return pool, nil
+}
+
 func (c *client) Type() string {
 	return model.GitlabType
 }
 
-func (c *client) CreateHook(receiver *v1.GitWebHookReceiver, accessToken string) error {
-	user, repo, err := getUserRepoFromURL(receiver.Spec.RepositoryURL)
+func (c *client) CreateHook(ctx context.Context, receiver *v1.GitWebHookReceiver, accessToken string) error {
+	projectPath, err := getProjectPathFromURL(receiver.Spec.RepositoryURL)
 	if err != nil {
 		return err
 	}
-	project := url.QueryEscape(user + "/" + repo)
+	project := url.QueryEscape(projectPath)
 	hookURL := fmt.Sprintf("%s/%s%s", settings.ServerURL.Get(), utils.HooksEndpointPrefix, ref.Ref(receiver))
-	opt := &gitlab.AddProjectHookOptions{
-		PushEvents:            gitlab.Bool(true),
-		MergeRequestsEvents:   gitlab.Bool(true),
-		TagPushEvents:         gitlab.Bool(true),
-		URL:                   gitlab.String(hookURL),
-		EnableSSLVerification: gitlab.Bool(false),
-		Token:                 gitlab.String(receiver.Status.Token),
-	}
-	url := fmt.Sprintf("%s/projects/%s/hooks", c.API, project)
-	_, err = doRequestToGitlab(http.MethodPost, url, accessToken, opt)
+	events := webhookEventsFrom(receiver.Spec.Events)
+	sslVerify := receiver.Spec.EnableSSLVerification
+	opt := c.flavor.hookOptions(hookURL, receiver.Status.Token, events, sslVerify)
+
+	existing, err := c.getHook(ctx, receiver, accessToken)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		url := fmt.Sprintf("%s/projects/%s/hooks", c.API, project)
+		_, err = c.doRequestToGitlab(ctx, http.MethodPost, url, accessToken, opt)
+		return err
+	}
+
+	if !hookNeedsUpdate(existing, hookURL, events, sslVerify) {
+		return nil
+	}
+	url := fmt.Sprintf("%s/projects/%s/hooks/%v", c.API, project, existing.ID)
+	_, err = c.doRequestToGitlab(ctx, http.MethodPut, url, accessToken, opt)
 	return err
 }
 
-func (c *client) DeleteHook(receiver *v1.GitWebHookReceiver, accessToken string) error {
-	user, repo, err := getUserRepoFromURL(receiver.Spec.RepositoryURL)
+// hookNeedsUpdate reports whether the configured hook's URL, event mask, or
+// SSL verification setting has drifted from the desired state.
+func hookNeedsUpdate(hook *gitlab.ProjectHook, hookURL string, events webhookEvents, sslVerify bool) bool {
+	return hook.URL != hookURL ||
+		hook.PushEvents != events.Push ||
+		hook.MergeRequestsEvents != events.MergeRequest ||
+		hook.TagPushEvents != events.Tag ||
+		hook.NoteEvents != events.Note ||
+		hook.ConfidentialNoteEvents != events.ConfidentialNote ||
+		hook.ConfidentialIssuesEvents != events.ConfidentialIssues ||
+		hook.PipelineEvents != events.Pipeline ||
+		hook.WikiPageEvents != events.Wiki ||
+		hook.DeploymentEvents != events.Deployment ||
+		hook.ReleasesEvents != events.Releases ||
+		hook.EnableSSLVerification != sslVerify
+}
+
+// RotateSecret generates a new webhook secret token for receiver's GitLab
+// hook and updates the hook in place via PUT, rather than deleting and
+// re-creating it, which would lose GitLab's webhook delivery history.
+func (c *client) RotateSecret(ctx context.Context, receiver *v1.GitWebHookReceiver, accessToken string) (string, error) {
+	projectPath, err := getProjectPathFromURL(receiver.Spec.RepositoryURL)
+	if err != nil {
+		return "", err
+	}
+	project := url.QueryEscape(projectPath)
+
+	hook, err := c.getHook(ctx, receiver, accessToken)
+	if err != nil {
+		return "", err
+	}
+	if hook == nil {
+		return "", fmt.Errorf("no gitlab hook found for receiver %v", ref.Ref(receiver))
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	hookURL := fmt.Sprintf("%s/%s%s", settings.ServerURL.Get(), utils.HooksEndpointPrefix, ref.Ref(receiver))
+	events := webhookEventsFrom(receiver.Spec.Events)
+	opt := c.flavor.hookOptions(hookURL, secret, events, receiver.Spec.EnableSSLVerification)
+	url := fmt.Sprintf("%s/projects/%s/hooks/%v", c.API, project, hook.ID)
+	if _, err := c.doRequestToGitlab(ctx, http.MethodPut, url, accessToken, opt); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (c *client) DeleteHook(ctx context.Context, receiver *v1.GitWebHookReceiver, accessToken string) error {
+	projectPath, err := getProjectPathFromURL(receiver.Spec.RepositoryURL)
 	if err != nil {
 		return err
 	}
-	project := url.QueryEscape(user + "/" + repo)
+	project := url.QueryEscape(projectPath)
 
-	hook, err := c.getHook(receiver, accessToken)
+	hook, err := c.getHook(ctx, receiver, accessToken)
 	if err != nil {
 		return err
 	}
 	if hook != nil {
 		url := fmt.Sprintf("%s/projects/%s/hooks/%v", c.API, project, hook.ID)
-		resp, err := doRequestToGitlab(http.MethodDelete, url, accessToken, nil)
+		resp, err := c.doRequestToGitlab(ctx, http.MethodDelete, url, accessToken, nil)
 		if err != nil {
 			return err
 		}
@@ -115,22 +277,17 @@ func (c *client) DeleteHook(receiver *v1.GitWebHookReceiver, accessToken string)
 	return nil
 }
 
-func (c *client) UpdateStatus(execution *v1.GitWebHookExecution, accessToken string) error {
-	user, repo, err := getUserRepoFromURL(execution.Spec.RepositoryURL)
+func (c *client) UpdateStatus(ctx context.Context, execution *v1.GitWebHookExecution, accessToken string) error {
+	projectPath, err := getProjectPathFromURL(execution.Spec.RepositoryURL)
 	if err != nil {
 		return err
 	}
-	project := url.QueryEscape(user + "/" + repo)
+	project := url.QueryEscape(projectPath)
 	status, desc := convertStatusDesc(execution)
 	commit := execution.Spec.Commit
-	opt := &gitlab.SetCommitStatusOptions{
-		State:       status,
-		Context:     gitlab.String(utils.StatusContext),
-		TargetURL:   gitlab.String(execution.Status.StatusURL),
-		Description: gitlab.String(desc),
-	}
+	opt := c.flavor.statusOptions(status, execution.Status.StatusURL, desc)
 	url := fmt.Sprintf("%s/projects/%s/statuses/%s", c.API, project, commit)
-	_, err = doRequestToGitlab(http.MethodPost, url, accessToken, opt)
+	_, err = c.doRequestToGitlab(ctx, http.MethodPost, url, accessToken, opt)
 	return err
 }
 
@@ -148,49 +305,168 @@ func convertStatusDesc(execution *v1.GitWebHookExecution) (gitlab.BuildStateValu
 	}
 }
 
-func (c *client) getHook(receiver *v1.GitWebHookReceiver, accessToken string) (*gitlab.ProjectHook, error) {
-	user, repo, err := getUserRepoFromURL(receiver.Spec.RepositoryURL)
+func (c *client) getHook(ctx context.Context, receiver *v1.GitWebHookReceiver, accessToken string) (*gitlab.ProjectHook, error) {
+	projectPath, err := getProjectPathFromURL(receiver.Spec.RepositoryURL)
 	if err != nil {
 		return nil, err
 	}
-	project := url.QueryEscape(user + "/" + repo)
+	project := url.QueryEscape(projectPath)
+	wantSuffix := fmt.Sprintf("%s%s", utils.HooksEndpointPrefix, ref.Ref(receiver))
 
-	var hooks []gitlab.ProjectHook
 	var result *gitlab.ProjectHook
-	url := fmt.Sprintf(c.API+"/projects/%s/hooks", project)
-	resp, err := getFromGitlab(accessToken, url)
+	listURL := fmt.Sprintf(c.API+"/projects/%s/hooks", project)
+	err = c.listPaginated(ctx, accessToken, listURL, func(page []byte) error {
+		var hooks []gitlab.ProjectHook
+		if err := json.Unmarshal(page, &hooks); err != nil {
+			return err
+		}
+		for _, hook := range hooks {
+			if strings.HasSuffix(hook.URL, wantSuffix) {
+				h := hook
+				result = &h
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	b, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	return result, nil
+}
+
+func (c *client) getFromGitlab(ctx context.Context, gitlabAccessToken string, url string) (*http.Response, error) {
+	return c.doRequestToGitlab(ctx, http.MethodGet, url, gitlabAccessToken, nil)
+}
+
+// listPaginated walks a GitLab list endpoint page by page, following the
+// "next" link from the response's Link header (falling back to
+// X-Next-Page), and invokes handlePage with the raw body of each page.
+// Future list endpoints (branches, tags, merge requests) can reuse this
+// instead of each reimplementing the pagination loop.
+func (c *client) listPaginated(ctx context.Context, accessToken, listURL string, handlePage func([]byte) error) error {
+	for listURL != "" {
+		resp, err := c.getFromGitlab(ctx, accessToken, listURL)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if err := handlePage(b); err != nil {
+			return err
+		}
+		listURL = nextPageURL(resp)
 	}
+	return nil
+}
 
-	if err := json.Unmarshal(b, &hooks); err != nil {
-		return nil, err
+// nextPageURL returns the URL of the next page of a GitLab list response, or
+// "" if the current page is the last one. It prefers the standard Link
+// header and falls back to GitLab's X-Next-Page header.
+func nextPageURL(resp *http.Response) string {
+	for _, part := range strings.Split(resp.Header.Get("Link"), ",") {
+		segments := strings.Split(strings.TrimSpace(part), ";")
+		if len(segments) < 2 {
+			continue
+		}
+		if strings.TrimSpace(segments[1]) != `rel="next"` {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(segments[0]), "<>")
 	}
-	for _, hook := range hooks {
-		if strings.HasSuffix(hook.URL, fmt.Sprintf("%s%s", utils.HooksEndpointPrefix, ref.Ref(receiver))) {
-			result = &hook
+
+	nextPage := resp.Header.Get("X-Next-Page")
+	if nextPage == "" {
+		return ""
+	}
+	reqURL := resp.Request.URL
+	q := reqURL.Query()
+	q.Set("page", nextPage)
+	reqURL.RawQuery = q.Encode()
+	return reqURL.String()
+}
+
+// doRequestToGitlab issues the request, retrying on rate-limiting (429) and
+// transient server errors (network failures, 502/503/504) with exponential
+// backoff. Retry-After and RateLimit-Reset response headers are honored when
+// present. POST (hook creation) is only retried for failures that are
+// clearly transient; it is never retried on 4xx validation errors. The
+// retries (and the wait between them) are bound by ctx, so a caller can
+// abort a call that would otherwise block for the full backoff schedule.
+func (c *client) doRequestToGitlab(ctx context.Context, method string, url string, gitlabAccessToken string, opt interface{}) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = c.doRequestToGitlabOnce(ctx, method, url, gitlabAccessToken, opt)
+		if err == nil || attempt >= c.MaxRetries || !isRetryableRequestError(method, resp, err) {
+			return resp, err
+		}
+		if err := sleepOrCancel(ctx, retryDelay(resp, attempt, c.BaseDelay, c.MaxDelay)); err != nil {
+			return resp, err
 		}
 	}
-	return result, nil
 }
 
-func getFromGitlab(gitlabAccessToken string, url string) (*http.Response, error) {
-	return doRequestToGitlab(http.MethodGet, url, gitlabAccessToken, nil)
+// sleepOrCancel waits for d, returning early with ctx.Err() if ctx is
+// cancelled first.
+func sleepOrCancel(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isRetryableRequestError reports whether a failed request should be
+// retried. Network errors and 429/502/503/504 are retryable for any verb;
+// other 5xx responses are retryable for idempotent verbs only, since a
+// non-idempotent POST (e.g. hook creation) may have partially succeeded.
+func isRetryableRequestError(method string, resp *http.Response, err error) bool {
+	if resp == nil {
+		return err != nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return method != http.MethodPost && resp.StatusCode >= http.StatusInternalServerError
 }
 
-func doRequestToGitlab(method string, url string, gitlabAccessToken string, opt interface{}) (*http.Response, error) {
-	req, err := http.NewRequest(method, url, nil)
+// retryDelay picks how long to wait before the next attempt, preferring the
+// server-provided Retry-After/RateLimit-Reset headers over the default
+// exponential backoff.
+func retryDelay(resp *http.Response, attempt int, base, max time.Duration) time.Duration {
+	if resp != nil {
+		if ra := resp.Header.Get("Retry-After"); ra != "" {
+			if secs, err := strconv.Atoi(ra); err == nil && secs >= 0 {
+				return time.Duration(secs) * time.Second
+			}
+		}
+		if reset := resp.Header.Get("RateLimit-Reset"); reset != "" {
+			if ts, err := strconv.ParseInt(reset, 10, 64); err == nil {
+				if d := time.Until(time.Unix(ts, 0)); d > 0 {
+					return d
+				}
+			}
+		}
+	}
+	delay := base * time.Duration(1<<uint(attempt))
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+func (c *client) doRequestToGitlabOnce(ctx context.Context, method string, url string, gitlabAccessToken string, opt interface{}) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	client := &http.Client{
-		Timeout: 15 * time.Second,
-	}
 	//set to max 100 per page to reduce query time
 	if method == http.MethodGet {
 		q := req.URL.Query()
@@ -213,7 +489,7 @@ func doRequestToGitlab(method string, url string, gitlabAccessToken string, opt
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("user-agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_10_5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/51.0.2704.103 Safari/537.36)")
-	resp, err := client.Do(req)
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return resp, err
 	}
@@ -221,17 +497,195 @@ func doRequestToGitlab(method string, url string, gitlabAccessToken string, opt
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
 		var body bytes.Buffer
 		io.Copy(&body, resp.Body)
+		resp.Body.Close()
 		return resp, httperror.NewAPIErrorLong(resp.StatusCode, "", body.String())
 	}
 
 	return resp, nil
 }
 
-func getUserRepoFromURL(repoURL string) (string, string, error) {
-	reg := regexp.MustCompile(".*/([^/]*?)/([^/]*?).git")
-	match := reg.FindStringSubmatch(repoURL)
-	if len(match) != 3 {
-		return "", "", fmt.Errorf("error getting user/repo from gitrepoUrl:%v", repoURL)
+// getProjectPathFromURL extracts the full namespace/project path from a
+// gitrepoUrl, e.g. "https://gitlab.example.com/group/subgroup/team/project.git"
+// or "git@gitlab.example.com:group/subgroup/team/project" yields
+// "group/subgroup/team/project". Unlike a plain user/repo split this supports
+// GitLab's arbitrarily deep group/subgroup namespaces.
+func getProjectPathFromURL(repoURL string) (string, error) {
+	trimmed := strings.TrimSuffix(strings.TrimSpace(repoURL), ".git")
+
+	var path string
+	if idx := strings.Index(trimmed, "://"); idx != -1 {
+		// https://host/group/subgroup/project
+		rest := trimmed[idx+len("://"):]
+		parts := strings.SplitN(rest, "/", 2)
+		if len(parts) != 2 {
+			return "", fmt.Errorf("error getting project path from gitrepoUrl:%v", repoURL)
+		}
+		path = parts[1]
+	} else if at := strings.Index(trimmed, "@"); at != -1 {
+		// git@host:group/subgroup/project
+		colon := strings.Index(trimmed[at:], ":")
+		if colon == -1 {
+			return "", fmt.Errorf("error getting project path from gitrepoUrl:%v", repoURL)
+		}
+		path = trimmed[at+colon+1:]
+	} else {
+		return "", fmt.Errorf("error getting project path from gitrepoUrl:%v", repoURL)
 	}
-	return match[1], match[2], nil
+
+	path = strings.Trim(path, "/")
+	if path == "" || !strings.Contains(path, "/") {
+		return "", fmt.Errorf("error getting project path from gitrepoUrl:%v", repoURL)
+	}
+	return path, nil
+}
+
+// apiFlavor isolates the request-shape differences between GitLab's v4 API
+// and the v3 API still exposed by pre-9.0 self-hosted GitLab CE instances,
+// so CreateHook/DeleteHook/UpdateStatus/getHook stay version-agnostic.
+type apiFlavor interface {
+	apiBase(scheme, host string) string
+	hookOptions(hookURL, token string, events webhookEvents, sslVerify bool) interface{}
+	statusOptions(state gitlab.BuildStateValue, targetURL, desc string) interface{}
+}
+
+// webhookEvents is the event mask selected via
+// v1.GitWebHookReceiverSpec.Events. An empty selector preserves the
+// historical default of push, merge request, and tag push events.
+type webhookEvents struct {
+	Push               bool
+	Tag                bool
+	MergeRequest       bool
+	Note               bool
+	Pipeline           bool
+	Wiki               bool
+	Deployment         bool
+	Releases           bool
+	ConfidentialNote   bool
+	ConfidentialIssues bool
+}
+
+func webhookEventsFrom(selected []string) webhookEvents {
+	if len(selected) == 0 {
+		return webhookEvents{Push: true, MergeRequest: true, Tag: true}
+	}
+	var events webhookEvents
+	for _, e := range selected {
+		switch e {
+		case "push":
+			events.Push = true
+		case "tag":
+			events.Tag = true
+		case "merge_request":
+			events.MergeRequest = true
+		case "note":
+			events.Note = true
+		case "pipeline":
+			events.Pipeline = true
+		case "wiki":
+			events.Wiki = true
+		case "deployment":
+			events.Deployment = true
+		case "releases":
+			events.Releases = true
+		case "confidential_note":
+			events.ConfidentialNote = true
+		case "confidential_issues":
+			events.ConfidentialIssues = true
+		}
+	}
+	return events
+}
+
+// apiFlavorFor returns the apiFlavor matching the configured API version,
+// defaulting to v4 (mirrors Drone/Woodpecker's DRONE_GITLAB_V3_API opt-in).
+func apiFlavorFor(apiVersion string) apiFlavor {
+	if strings.EqualFold(apiVersion, apiVersionV3) {
+		return v3Flavor{}
+	}
+	return v4Flavor{}
+}
+
+type v4Flavor struct{}
+
+func (v4Flavor) apiBase(scheme, host string) string {
+	return fmt.Sprintf(gitlabAPIv4Fmt, scheme, host)
+}
+
+func (v4Flavor) hookOptions(hookURL, token string, events webhookEvents, sslVerify bool) interface{} {
+	return &gitlab.AddProjectHookOptions{
+		PushEvents:               gitlab.Bool(events.Push),
+		MergeRequestsEvents:      gitlab.Bool(events.MergeRequest),
+		TagPushEvents:            gitlab.Bool(events.Tag),
+		NoteEvents:               gitlab.Bool(events.Note),
+		ConfidentialNoteEvents:   gitlab.Bool(events.ConfidentialNote),
+		ConfidentialIssuesEvents: gitlab.Bool(events.ConfidentialIssues),
+		PipelineEvents:           gitlab.Bool(events.Pipeline),
+		WikiPageEvents:           gitlab.Bool(events.Wiki),
+		DeploymentEvents:         gitlab.Bool(events.Deployment),
+		ReleasesEvents:           gitlab.Bool(events.Releases),
+		URL:                      gitlab.String(hookURL),
+		EnableSSLVerification:    gitlab.Bool(sslVerify),
+		Token:                    gitlab.String(token),
+	}
+}
+
+func (v4Flavor) statusOptions(state gitlab.BuildStateValue, targetURL, desc string) interface{} {
+	return &gitlab.SetCommitStatusOptions{
+		State:       state,
+		Context:     gitlab.String(utils.StatusContext),
+		TargetURL:   gitlab.String(targetURL),
+		Description: gitlab.String(desc),
+	}
+}
+
+type v3Flavor struct{}
+
+func (v3Flavor) apiBase(scheme, host string) string {
+	return fmt.Sprintf(gitlabAPIv3Fmt, scheme, host)
+}
+
+// hookOptions intentionally drops pipeline/wiki/deployment/releases/
+// confidential_* events: those GitLab features postdate the v3 API and have
+// no corresponding hook parameter there.
+func (v3Flavor) hookOptions(hookURL, token string, events webhookEvents, sslVerify bool) interface{} {
+	return &v3ProjectHookOptions{
+		URL:                   hookURL,
+		PushEvents:            events.Push,
+		MergeRequestsEvents:   events.MergeRequest,
+		TagPushEvents:         events.Tag,
+		NoteEvents:            events.Note,
+		EnableSSLVerification: sslVerify,
+		Token:                 token,
+	}
+}
+
+func (v3Flavor) statusOptions(state gitlab.BuildStateValue, targetURL, desc string) interface{} {
+	return &v3CommitStatusOptions{
+		State:       string(state),
+		Name:        utils.StatusContext,
+		TargetURL:   targetURL,
+		Description: desc,
+	}
+}
+
+// v3ProjectHookOptions mirrors gitlab.AddProjectHookOptions for the v3 API,
+// where the webhook-notes toggle is named "notes_events" instead of v4's
+// "note_events".
+type v3ProjectHookOptions struct {
+	URL                   string `url:"url"`
+	PushEvents            bool   `url:"push_events"`
+	MergeRequestsEvents   bool   `url:"merge_requests_events"`
+	TagPushEvents         bool   `url:"tag_push_events"`
+	NoteEvents            bool   `url:"notes_events"`
+	EnableSSLVerification bool   `url:"enable_ssl_verification"`
+	Token                 string `url:"token"`
+}
+
+// v3CommitStatusOptions mirrors gitlab.SetCommitStatusOptions for the v3
+// API, which names the status context "name" instead of v4's "context".
+type v3CommitStatusOptions struct {
+	State       string `url:"state"`
+	Name        string `url:"name"`
+	TargetURL   string `url:"target_url"`
+	Description string `url:"description"`
 }
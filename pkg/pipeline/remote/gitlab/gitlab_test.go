@@ -0,0 +1,86 @@
+package gitlab
+
+import "testing"
+
+func TestGetProjectPathFromURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoURL string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "https with .git suffix",
+			repoURL: "https://gitlab.example.com/group/project.git",
+			want:    "group/project",
+		},
+		{
+			name:    "https without .git suffix",
+			repoURL: "https://gitlab.example.com/group/project",
+			want:    "group/project",
+		},
+		{
+			name:    "http without .git suffix",
+			repoURL: "http://gitlab.example.com/group/project",
+			want:    "group/project",
+		},
+		{
+			name:    "ssh git@ URL",
+			repoURL: "git@gitlab.example.com:group/project.git",
+			want:    "group/project",
+		},
+		{
+			name:    "ssh git@ URL without .git suffix",
+			repoURL: "git@gitlab.example.com:group/project",
+			want:    "group/project",
+		},
+		{
+			name:    "https two level subgroup",
+			repoURL: "https://gitlab.example.com/group/subgroup/project.git",
+			want:    "group/subgroup/project",
+		},
+		{
+			name:    "https three level subgroup",
+			repoURL: "https://gitlab.example.com/group/subgroup/team/project.git",
+			want:    "group/subgroup/team/project",
+		},
+		{
+			name:    "ssh git@ URL with nested subgroups",
+			repoURL: "git@gitlab.example.com:group/subgroup/team/project.git",
+			want:    "group/subgroup/team/project",
+		},
+		{
+			name:    "gitlab.com top level project",
+			repoURL: "https://gitlab.com/group/project.git",
+			want:    "group/project",
+		},
+		{
+			name:    "no namespace is an error",
+			repoURL: "https://gitlab.example.com/project.git",
+			wantErr: true,
+		},
+		{
+			name:    "unparseable URL is an error",
+			repoURL: "not-a-url",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getProjectPathFromURL(tt.repoURL)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("getProjectPathFromURL(%q) = %q, want error", tt.repoURL, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getProjectPathFromURL(%q) returned unexpected error: %v", tt.repoURL, err)
+			}
+			if got != tt.want {
+				t.Errorf("getProjectPathFromURL(%q) = %q, want %q", tt.repoURL, got, tt.want)
+			}
+		})
+	}
+}
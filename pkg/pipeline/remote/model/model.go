@@ -0,0 +1,30 @@
+package model
+
+import (
+	"context"
+
+	"github.com/rancher/webhookinator/types/apis/webhookinator.cattle.io/v1"
+)
+
+const (
+	GitlabType    = "gitlab"
+	GithubType    = "github"
+	BitbucketType = "bitbucket"
+)
+
+// Remote is the interface each supported git provider implements to manage
+// webhook receivers and report pipeline execution status back to the
+// remote. Every call that talks to the remote takes a context so a caller
+// can bound or cancel a request, including the retries a Remote may perform
+// internally.
+type Remote interface {
+	Type() string
+	CreateHook(ctx context.Context, receiver *v1.GitWebHookReceiver, accessToken string) error
+	DeleteHook(ctx context.Context, receiver *v1.GitWebHookReceiver, accessToken string) error
+	UpdateStatus(ctx context.Context, execution *v1.GitWebHookExecution, accessToken string) error
+
+	// RotateSecret replaces a receiver's webhook secret token in place and
+	// returns the new secret, so operators can rotate credentials without
+	// losing the remote's webhook delivery history.
+	RotateSecret(ctx context.Context, receiver *v1.GitWebHookReceiver, accessToken string) (string, error)
+}
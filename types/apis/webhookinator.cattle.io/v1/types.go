@@ -0,0 +1,80 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitWebHookReceiver represents a configured inbound webhook receiver for a
+// single git repository.
+type GitWebHookReceiver struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitWebHookReceiverSpec   `json:"spec,omitempty"`
+	Status GitWebHookReceiverStatus `json:"status,omitempty"`
+}
+
+type GitWebHookReceiverSpec struct {
+	RepositoryURL string `json:"repositoryUrl,omitempty"`
+
+	// Events selects which webhook event types the remote should notify
+	// this receiver about: push, tag, merge_request, note, pipeline,
+	// wiki, deployment, releases, confidential_note, confidential_issues.
+	// A nil/empty selector preserves the historical default of push,
+	// merge_request, and tag events.
+	Events []string `json:"events,omitempty"`
+
+	// EnableSSLVerification controls whether the git remote verifies TLS
+	// certificates when delivering webhook payloads to this receiver.
+	EnableSSLVerification bool `json:"enableSSLVerification,omitempty"`
+}
+
+type GitWebHookReceiverStatus struct {
+	Token string `json:"token,omitempty"`
+}
+
+// GitWebHookExecution records a single delivered webhook/pipeline run.
+type GitWebHookExecution struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitWebHookExecutionSpec   `json:"spec,omitempty"`
+	Status GitWebHookExecutionStatus `json:"status,omitempty"`
+}
+
+type GitWebHookExecutionSpec struct {
+	RepositoryURL string `json:"repositoryUrl,omitempty"`
+	Commit        string `json:"commit,omitempty"`
+}
+
+type GitWebHookExecutionStatus struct {
+	StatusURL  string      `json:"statusUrl,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// Condition is a minimal stand-in for norman's generated condition-status
+// tracking, sufficient for GitWebHookExecutionConditionHandled.GetStatus.
+type Condition struct {
+	Type   string `json:"type,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+// GitWebHookExecutionCondition is a condition type recognized on
+// GitWebHookExecution.Status.Conditions.
+type GitWebHookExecutionCondition string
+
+const (
+	GitWebHookExecutionConditionHandled GitWebHookExecutionCondition = "Handled"
+)
+
+// GetStatus returns the string value ("True"/"False"/"Unknown"/"") last
+// recorded for this condition on obj, mirroring norman's generated
+// condition accessors.
+func (c GitWebHookExecutionCondition) GetStatus(obj *GitWebHookExecution) string {
+	for _, cond := range obj.Status.Conditions {
+		if cond.Type == string(c) {
+			return cond.Status
+		}
+	}
+	return ""
+}
@@ -0,0 +1,28 @@
+package v3
+
+// GitlabPipelineConfig is the user-facing configuration for connecting a
+// pipeline/webhook receiver to a GitLab (gitlab.com or self-hosted)
+// instance.
+type GitlabPipelineConfig struct {
+	Hostname     string `json:"hostname,omitempty"`
+	TLS          bool   `json:"tls,omitempty"`
+	ClientID     string `json:"clientId,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty" norman:"type=password"`
+	RedirectURL  string `json:"redirectUrl,omitempty"`
+
+	// APIVersion selects the GitLab API version to target. Defaults to v4;
+	// set to "v3" for pre-9.0 self-hosted GitLab CE instances that only
+	// expose the v3 API (mirrors Drone/Woodpecker's DRONE_GITLAB_V3_API).
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// CAFile/CAPath/InsecureSkipVerify/ClientCertFile/ClientKeyFile cover
+	// self-hosted GitLab installs that use a private CA, a self-signed
+	// certificate, or mutual TLS, mirroring the
+	// HTTP{User,Password,SelfSigned,CAFile,CAPath} shape used by Gitaly's
+	// config.
+	CAFile             string `json:"caFile,omitempty"`
+	CAPath             string `json:"caPath,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+	ClientCertFile     string `json:"clientCertFile,omitempty"`
+	ClientKeyFile      string `json:"clientKeyFile,omitempty"`
+}